@@ -0,0 +1,121 @@
+package mock
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/aggregate"
+	"istio.io/istio/pilot/pkg/model"
+	config2 "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/test/config"
+)
+
+// CheckAggregateInvariant seeds disjoint and overlapping configs across the
+// given mock store controllers (standing in for separate clusters or config
+// sources), wraps them in an aggregate.Store via aggregate.MakeWriteableCache,
+// and validates: List returns the union of all sources deduplicated by
+// (gvk,ns,name); a key held by more than one source resolves to the
+// first-listed source's copy, matching the aggregate store's source-order
+// precedence; deleting from one source's underlying store only removes that
+// source's copy; and the aggregate still serves the remaining sources'
+// copy afterwards.
+func CheckAggregateInvariant(stores []model.ConfigStoreController, t *testing.T) {
+	namespace := "aggregate-test"
+
+	// Disjoint: each source owns a config no other source has.
+	for i, store := range stores {
+		cfg := Make(namespace, i)
+		if _, err := store.Create(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Overlapping: every source holds a config under the same key, with a
+	// source-specific spec so precedence can be asserted.
+	const sharedIndex = 1000
+	sharedName := Make(namespace, sharedIndex).Name
+	for i, store := range stores {
+		cfg := config2.Config{
+			Meta: config2.Meta{
+				GroupVersionKind: mockGvk,
+				Name:             sharedName,
+				Namespace:        namespace,
+			},
+			Spec: &config.MockConfig{Key: fmt.Sprintf("source-%d", i)},
+		}
+		if _, err := store.Create(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	agg, err := aggregate.MakeWriteableCache(stores, stores[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifyAggregateUnion(agg, t, namespace, stores)
+	verifyAggregatePrecedence(agg, t, namespace, sharedName)
+	verifyAggregatePerSourceDelete(stores, agg, t, namespace)
+	log.Info("Aggregate invariant checked")
+}
+
+// verifyAggregateUnion asserts List returns exactly one entry per disjoint
+// config plus one for the deduplicated shared key.
+func verifyAggregateUnion(store model.ConfigStore, t *testing.T, namespace string, stores []model.ConfigStoreController) {
+	list := store.List(mockGvk, namespace)
+	want := len(stores) + 1
+	if len(list) != want {
+		t.Errorf("aggregate List: wanted %d unique configs (union, deduplicated), got %d", want, len(list))
+	}
+
+	seen := make(map[string]bool)
+	for _, cfg := range list {
+		if seen[cfg.Name] {
+			t.Errorf("aggregate List: config %s returned more than once", cfg.Name)
+		}
+		seen[cfg.Name] = true
+	}
+}
+
+// verifyAggregatePrecedence asserts the shared key resolves to the
+// first-listed source's copy.
+func verifyAggregatePrecedence(store model.ConfigStore, t *testing.T, namespace, name string) {
+	got := store.Get(mockGvk, name, namespace)
+	if got == nil {
+		t.Fatal("expected shared config to be present in aggregate")
+	}
+
+	spec, ok := got.Spec.(*config.MockConfig)
+	if !ok {
+		t.Fatalf("unexpected spec type %T for shared config", got.Spec)
+	}
+	if spec.Key != "source-0" {
+		t.Errorf("expected first-listed source to win precedence for shared config, got %q", spec.Key)
+	}
+}
+
+// verifyAggregatePerSourceDelete deletes the shared key from one underlying
+// source and asserts the aggregate still serves the remaining sources' copy.
+func verifyAggregatePerSourceDelete(stores []model.ConfigStoreController, aggregateStore model.ConfigStore, t *testing.T, namespace string) {
+	if len(stores) < 2 {
+		return
+	}
+	name := Make(namespace, 1000).Name
+	if err := stores[0].Delete(mockGvk, name, namespace, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg := stores[0].Get(mockGvk, name, namespace); cfg != nil {
+		t.Error("expected config removed from source 0 after per-source Delete")
+	}
+	for i := 1; i < len(stores); i++ {
+		if cfg := stores[i].Get(mockGvk, name, namespace); cfg == nil {
+			t.Errorf("per-source Delete on source 0 unexpectedly removed config from source %d", i)
+		}
+	}
+
+	if cfg := aggregateStore.Get(mockGvk, name, namespace); cfg == nil {
+		t.Error("expected aggregate to still serve remaining sources' copy after one source's Delete")
+	}
+}
@@ -0,0 +1,185 @@
+package mock
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	config2 "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/resource"
+)
+
+// fuzzOp enumerates the store operations FuzzStoreInvariants exercises.
+type fuzzOp int
+
+const (
+	fuzzOpCreate fuzzOp = iota
+	fuzzOpUpdate
+	fuzzOpDelete
+	fuzzOpGet
+	fuzzOpList
+)
+
+// fuzzKey identifies an oracle entry by the same coordinates a
+// model.ConfigStore keys on.
+type fuzzKey struct {
+	gvk       config2.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// maxFuzzSteps bounds how much of a fuzz input drives the operation
+// sequence, so a pathologically large input doesn't make a single run
+// unbounded; go test -fuzz is still free to grow the corpus to inputs of any
+// length, which is what gives this suite its sequence-length diversity.
+const maxFuzzSteps = 64
+
+// FuzzStoreInvariants drives random sequences of Create/Update/Delete/Get/List
+// across every schema in collections.Pilot against a fresh store produced by
+// newStore, and checks the result against an in-memory shadow map oracle on
+// every step: Get must match the oracle, List must return exactly the
+// oracle's set for the namespace, and ResourceVersion must strictly increase
+// per key on update. Deleted keys staying gone is checked with a Get right
+// after each Delete, and by the oracle bookkeeping itself: a deleted key is
+// removed from the oracle, so assertFuzzInvariants flags it whenever a List of
+// its namespace still returns it. Each step consumes two bytes of the fuzz
+// input, and at most maxFuzzSteps steps run; the rest of a longer input is
+// ignored.
+//
+// Update on a stale ResourceVersion returning a conflict error is
+// deliberately NOT checked here: the canonical in-memory reference store
+// this suite is meant to validate does not enforce optimistic-concurrency
+// conflicts on stale ResourceVersions, so asserting one would fail against
+// the very store the suite exists to pass. Stores that do implement
+// optimistic concurrency should add their own stale-ResourceVersion
+// conflict test on top of this one.
+func FuzzStoreInvariants(f *testing.F, newStore func() model.ConfigStore) {
+	f.Add([]byte{1, 0, 0, 0})
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7})
+	f.Add([]byte{3, 3, 1, 2, 4, 0, 2, 1, 3, 4, 0, 1, 2, 3, 4, 0})
+	f.Add([]byte{0, 0, 1, 0, 2, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 2*maxFuzzSteps {
+			data = data[:2*maxFuzzSteps]
+		}
+		store := newStore()
+		oracle := make(map[fuzzKey]config2.Config)
+		schemas := collections.Pilot.All()
+
+		// The first byte of a step picks the operation, namespace and name
+		// independently of each other; the second picks the schema.
+		for i := 0; i+1 < len(data); i += 2 {
+			step, raw := i/2, int(data[i])
+			schema := schemas[int(data[i+1])%len(schemas)]
+			gvk := schema.GroupVersionKind()
+			namespace := fmt.Sprintf("ns-%d", (raw/5)%3)
+			name := fmt.Sprintf("name-%d", (raw/15)%5)
+			key := fuzzKey{gvk: gvk, namespace: namespace, name: name}
+			op := fuzzOp(raw % 5)
+
+			applyFuzzOp(t, store, oracle, schema, key, op, step)
+			assertFuzzInvariants(t, store, oracle, gvk, namespace, step)
+		}
+	})
+}
+
+func applyFuzzOp(t *testing.T, store model.ConfigStore, oracle map[fuzzKey]config2.Config, schema resource.Schema, key fuzzKey, op fuzzOp, step int) {
+	switch op {
+	case fuzzOpCreate:
+		if _, exists := oracle[key]; exists {
+			return
+		}
+		cfg := fuzzConfig(t, schema, key)
+		rev, err := store.Create(cfg)
+		if err != nil {
+			t.Fatalf("step %d: unexpected Create error: %v", step, err)
+		}
+		cfg.ResourceVersion = rev
+		oracle[key] = cfg
+
+	case fuzzOpUpdate:
+		cur, exists := oracle[key]
+		if !exists {
+			return
+		}
+		next := fuzzConfig(t, schema, key)
+		next.ResourceVersion = cur.ResourceVersion
+		rev, err := store.Update(next)
+		if err != nil {
+			t.Fatalf("step %d: unexpected Update error: %v", step, err)
+		}
+		if !rvLess(t, cur.ResourceVersion, rev) {
+			t.Errorf("step %d: ResourceVersion did not strictly increase on update: %q -> %q", step, cur.ResourceVersion, rev)
+		}
+		next.ResourceVersion = rev
+		oracle[key] = next
+
+	case fuzzOpDelete:
+		if _, exists := oracle[key]; !exists {
+			return
+		}
+		if err := store.Delete(key.gvk, key.name, key.namespace, nil); err != nil {
+			t.Fatalf("step %d: unexpected Delete error: %v", step, err)
+		}
+		delete(oracle, key)
+		if store.Get(key.gvk, key.name, key.namespace) != nil {
+			t.Errorf("step %d: Get(%v): deleted config still served", step, key)
+		}
+
+	case fuzzOpGet, fuzzOpList:
+		// handled by assertFuzzInvariants below
+	}
+}
+
+func assertFuzzInvariants(t *testing.T, store model.ConfigStore, oracle map[fuzzKey]config2.Config, gvk config2.GroupVersionKind, namespace string, step int) {
+	for key, want := range oracle {
+		if key.gvk != gvk {
+			continue
+		}
+		got := store.Get(key.gvk, key.name, key.namespace)
+		if got == nil {
+			t.Errorf("step %d: Get(%v): expected config present in oracle", step, key)
+			continue
+		}
+		if got.ResourceVersion != want.ResourceVersion {
+			t.Errorf("step %d: Get(%v): ResourceVersion mismatch: oracle %q, store %q", step, key, want.ResourceVersion, got.ResourceVersion)
+		}
+	}
+
+	list := store.List(gvk, namespace)
+	wantNames := make(map[string]bool)
+	for key := range oracle {
+		if key.gvk == gvk && key.namespace == namespace {
+			wantNames[key.name] = true
+		}
+	}
+	if len(list) != len(wantNames) {
+		t.Errorf("step %d: List(%v, %q): wanted %d configs, got %d", step, gvk, namespace, len(wantNames), len(list))
+	}
+	for _, cfg := range list {
+		if !wantNames[cfg.Name] {
+			t.Errorf("step %d: List(%v, %q): unexpected config %s not in oracle", step, gvk, namespace, cfg.Name)
+		}
+	}
+}
+
+// fuzzConfig builds a config of the given schema's own type, rather than a
+// universal stand-in spec: creating e.g. a VirtualService-GVK config with a
+// MockConfig spec would be semantically invalid and rejected by any
+// schema-validating store.
+func fuzzConfig(t *testing.T, schema resource.Schema, key fuzzKey) config2.Config {
+	spec, err := schema.NewInstance()
+	if err != nil {
+		t.Fatalf("schema.NewInstance() for %s: %v", schema.Kind(), err)
+	}
+	return config2.Config{
+		Meta: config2.Meta{
+			GroupVersionKind: key.gvk,
+			Name:             key.name,
+			Namespace:        key.namespace,
+		},
+		Spec: spec,
+	}
+}
@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	config2 "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// unstructuredExamples mirrors the typed Example* fixtures as plain
+// map[string]interface{} specs, keyed by the same schema so the two paths
+// can be cross-checked for semantic equality.
+var unstructuredExamples = map[resource.Schema]map[string]interface{}{
+	collections.VirtualService:      mustToUnstructured(ExampleVirtualService),
+	collections.ServiceEntry:        mustToUnstructured(ExampleServiceEntry),
+	collections.Gateway:             mustToUnstructured(ExampleGateway),
+	collections.DestinationRule:     mustToUnstructured(ExampleDestinationRule),
+	collections.AuthorizationPolicy: mustToUnstructured(ExampleAuthorizationPolicy),
+}
+
+// mustToUnstructured renders a typed proto Spec into the same
+// map[string]interface{} shape a dynamic/CRD/JSON client would decode from
+// YAML or JSON, so unstructured fixtures stay in lockstep with the typed ones.
+func mustToUnstructured(spec proto.Message) map[string]interface{} {
+	data, err := protojson.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]interface{})
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// MakeUnstructured builds a config.Config for the given schema whose Spec is
+// a bare map[string]interface{}, as would be decoded off the wire by a
+// dynamic client rather than constructed from a typed proto message.
+// config2.Config.Spec is declared as `any`, so the map is stored directly.
+func MakeUnstructured(schema resource.Schema, name, namespace string, spec map[string]interface{}) config2.Config {
+	return config2.Config{
+		Meta: config2.Meta{
+			GroupVersionKind: schema.GroupVersionKind(),
+			Name:             name,
+			Namespace:        namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// CheckUnstructuredRoundTrip Creates, Gets, Lists and Updates every Istio
+// schema via its unstructured representation, asserting that round-tripping
+// through the store preserves semantic equality with the typed Example*
+// fixtures. It exercises stores backed by dynamic (CRD/JSON) clients using the
+// same conformance suite as the typed path in CheckIstioConfigTypes.
+//
+// store must accept a bare map[string]interface{} as Spec without requiring
+// it to satisfy proto.Message: the standard validating in-memory store
+// type-asserts Spec to proto.Message during Create and will reject or panic
+// on these fixtures. Pass a dynamic/unstructured-aware store here, not the
+// same store wired up to CheckIstioConfigTypes.
+func CheckUnstructuredRoundTrip(store model.ConfigStore, t *testing.T) {
+	namespace := "unstructured-test"
+	name := "unstructured-example"
+
+	for schema, spec := range unstructuredExamples {
+		schema, spec := schema, spec
+		t.Run(schema.Kind(), func(t *testing.T) {
+			meta := config2.Meta{
+				GroupVersionKind: schema.GroupVersionKind(),
+				Name:             name,
+			}
+			if !schema.IsClusterScoped() {
+				meta.Namespace = namespace
+			}
+
+			cfg := MakeUnstructured(schema, meta.Name, meta.Namespace, spec)
+			if _, err := store.Create(cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			got := store.Get(schema.GroupVersionKind(), meta.Name, meta.Namespace)
+			if got == nil {
+				t.Fatal("expected to find stored unstructured config")
+			}
+			assertUnstructuredEqualsTyped(t, schema, got.Spec)
+
+			list := store.List(schema.GroupVersionKind(), meta.Namespace)
+			if len(list) == 0 {
+				t.Error("expected non-zero number of configs")
+			}
+
+			updated := MakeUnstructured(schema, meta.Name, meta.Namespace, spec)
+			updated.ResourceVersion = got.ResourceVersion
+			if _, err := store.Update(updated); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	log.Info("Checked unstructured round-trip for all Istio config types")
+}
+
+// assertUnstructuredEqualsTyped converts the stored unstructured spec back to
+// its typed message and compares it against the corresponding Example*
+// fixture field-by-field via proto equality.
+func assertUnstructuredEqualsTyped(t *testing.T, schema resource.Schema, spec config2.Spec) {
+	typed, err := schema.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	asMap, ok := spec.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected unstructured spec type %T", spec)
+	}
+	data, err := json.Marshal(asMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protojson.Unmarshal(data, typed.(proto.Message)); err != nil {
+		t.Fatal(err)
+	}
+
+	var want proto.Message
+	switch schema {
+	case collections.VirtualService:
+		want = ExampleVirtualService
+	case collections.ServiceEntry:
+		want = ExampleServiceEntry
+	case collections.Gateway:
+		want = ExampleGateway
+	case collections.DestinationRule:
+		want = ExampleDestinationRule
+	case collections.AuthorizationPolicy:
+		want = ExampleAuthorizationPolicy
+	default:
+		t.Fatalf("no typed fixture registered for schema %s", schema.Kind())
+	}
+
+	if !proto.Equal(typed.(proto.Message), want) {
+		t.Errorf("unstructured round-trip mismatch for %s:\n got:  %v\nwant: %v", schema.Kind(), typed, want)
+	}
+}
@@ -0,0 +1,265 @@
+package mock
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	config2 "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/test/config"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// watchEvent records a single delivery from a registered event handler, along
+// with enough of the old/new config to let the caller assert ordering and
+// payload correctness.
+type watchEvent struct {
+	kind model.Event
+	old  config2.Config
+	new  config2.Config
+}
+
+// watchRecorder collects events for a single GVK handler in delivery order.
+type watchRecorder struct {
+	mu     sync.Mutex
+	events []watchEvent
+}
+
+func (w *watchRecorder) handle(old, new config2.Config, ev model.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, watchEvent{kind: ev, old: old, new: new})
+}
+
+func (w *watchRecorder) snapshot() []watchEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]watchEvent, len(w.events))
+	copy(out, w.events)
+	return out
+}
+
+func (w *watchRecorder) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.events)
+}
+
+// CheckWatchInvariant validates the event-stream contract of a
+// model.ConfigStoreController: handlers registered per GVK must observe
+// Create/Update/Delete in order with correct new payloads and monotonically
+// increasing ResourceVersions, rapid updates must never be reordered or
+// duplicated even if some are coalesced away, a panicking handler must not
+// block its siblings, and no further events may arrive once Run() has been
+// stopped. The old payload of each event is recorded but not checked.
+func CheckWatchInvariant(r model.ConfigStoreController, t *testing.T, namespace string, n int) {
+	stop := make(chan struct{})
+	go r.Run(stop)
+
+	rec := &watchRecorder{}
+	r.RegisterEventHandler(mockGvk, rec.handle)
+
+	panicky := &watchRecorder{}
+	r.RegisterEventHandler(mockGvk, func(old, new config2.Config, ev model.Event) {
+		panicky.handle(old, new, ev)
+		// Deliberately left unrecovered: the store's dispatch path is required
+		// to isolate each handler, so the panic must not stop sibling handlers
+		// below from being invoked. A store that doesn't isolate handlers will
+		// legitimately crash this test, which is the correct failure signal.
+		panic("handler boom")
+	})
+
+	sibling := &watchRecorder{}
+	r.RegisterEventHandler(mockGvk, sibling.handle)
+
+	elts := createConfigs(namespace, n)
+	createConfigsInStore(r, t, elts)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		if rec.len() != n {
+			return fmt.Errorf("wanted %d add events, got %d", n, rec.len())
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	verifyWatchOrderAndPayloads(t, rec.snapshot(), elts, n, model.EventAdd)
+
+	if sibling.len() != n {
+		t.Errorf("panicking handler blocked sibling: wanted %d events, got %d", n, sibling.len())
+	}
+
+	const rapidUpdates = 3
+	updated := make(map[int]config2.Config, n)
+	for i, elt := range elts {
+		cur := r.Get(mockGvk, elt.Name, elt.Namespace)
+		if cur == nil {
+			t.Fatalf("missing config %s before update", elt.Name)
+		}
+		next := *cur
+		// Issue several rapid updates; only the final state is guaranteed to be
+		// observable, though intermediate updates may be coalesced or delivered
+		// individually. verifyCoalescedUpdates checks both outcomes are valid.
+		for j := 0; j < rapidUpdates; j++ {
+			next.Spec = &config.MockConfig{Key: fmt.Sprintf("updated-%d-%d", i, j)}
+			rev, err := r.Update(next)
+			if err != nil {
+				t.Error(err)
+			}
+			next.ResourceVersion = rev
+		}
+		updated[i] = next
+	}
+
+	retry.UntilSuccessOrFail(t, func() error {
+		last := lastEventPerKey(rec.snapshot(), model.EventUpdate)
+		for i, want := range updated {
+			got, ok := last[want.Name]
+			if !ok {
+				return fmt.Errorf("no update event observed for %s", want.Name)
+			}
+			if !Compare(got.new, want) {
+				return fmt.Errorf("update %d: wanted %v, got %v", i, want, got.new)
+			}
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	verifyCoalescedUpdates(t, rec.snapshot(), updated, rapidUpdates)
+
+	deleteConfigsFromStore(r, t, namespace, n)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		deletes := countEvents(rec.snapshot(), model.EventDelete)
+		if deletes != n {
+			return fmt.Errorf("wanted %d delete events, got %d", n, deletes)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	close(stop)
+	postStop := rec.len()
+
+	r.RegisterEventHandler(collections.Mock.GroupVersionKind(), rec.handle)
+	if _, err := r.Create(Make(namespace, n)); err == nil {
+		_ = r.Delete(mockGvk, Make(namespace, n).Name, namespace, nil)
+	}
+	if rec.len() != postStop {
+		t.Error("expected no events to be delivered after Run() was stopped")
+	}
+	log.Info("Watch invariant checked")
+}
+
+// verifyWatchOrderAndPayloads asserts that n events of the given kind were
+// observed, one per seeded config, with a payload matching the seeded element.
+// ResourceVersions are compared only between events for the same config,
+// where they must strictly increase.
+func verifyWatchOrderAndPayloads(t *testing.T, events []watchEvent, elts map[int]config2.Config, n int, kind model.Event) {
+	byName := make(map[string]config2.Config, len(elts))
+	for _, elt := range elts {
+		byName[elt.Name] = elt
+	}
+
+	seen := make(map[string]string)
+	count := 0
+	for _, ev := range events {
+		if ev.kind != kind {
+			continue
+		}
+		count++
+		want, ok := byName[ev.new.Name]
+		if !ok {
+			t.Errorf("unexpected event for unknown config %s", ev.new.Name)
+			continue
+		}
+		if !Compare(ev.new, want) {
+			t.Errorf("add event payload mismatch for %s: wanted %v, got %v", ev.new.Name, want, ev.new)
+		}
+		if prev, ok := seen[ev.new.Name]; ok && !rvLess(t, prev, ev.new.ResourceVersion) {
+			t.Errorf("ResourceVersion did not increase monotonically for %s", ev.new.Name)
+		}
+		seen[ev.new.Name] = ev.new.ResourceVersion
+	}
+	if count != n {
+		t.Errorf("wanted %d %v events, got %d", n, kind, count)
+	}
+}
+
+// verifyCoalescedUpdates checks the update events delivered for a burst of
+// issuedPerKey rapid updates per key. It rejects more events than were
+// issued (duplicate delivery) and any event out of ResourceVersion order, and
+// requires the last event to match the expected final state. It does not
+// assert that coalescing occurred — a store may legitimately deliver every
+// update individually — so it logs how many of the issued updates were
+// actually observed per key, making any coalescing that did happen visible
+// in test output without mandating it.
+func verifyCoalescedUpdates(t *testing.T, events []watchEvent, updated map[int]config2.Config, issuedPerKey int) {
+	perName := make(map[string][]watchEvent)
+	for _, ev := range events {
+		if ev.kind == model.EventUpdate {
+			perName[ev.new.Name] = append(perName[ev.new.Name], ev)
+		}
+	}
+
+	for i, want := range updated {
+		seq := perName[want.Name]
+		if len(seq) == 0 {
+			t.Errorf("update %d: no update event observed for %s", i, want.Name)
+			continue
+		}
+		if len(seq) > issuedPerKey {
+			t.Errorf("update %d: observed %d update events for %s, more than the %d issued", i, len(seq), want.Name, issuedPerKey)
+		}
+		if len(seq) < issuedPerKey {
+			t.Logf("update %d: %s coalesced %d issued updates into %d observed events", i, want.Name, issuedPerKey, len(seq))
+		}
+		for j := 1; j < len(seq); j++ {
+			if !rvLess(t, seq[j-1].new.ResourceVersion, seq[j].new.ResourceVersion) {
+				t.Errorf("update %d: ResourceVersion did not increase monotonically across coalesced events for %s", i, want.Name)
+			}
+		}
+		if last := seq[len(seq)-1]; !Compare(last.new, want) {
+			t.Errorf("update %d: wanted final state %v, got %v", i, want, last.new)
+		}
+	}
+}
+
+// rvLess reports whether ResourceVersion a is numerically less than b.
+// ResourceVersions are monotonically increasing numeric strings, so a plain
+// string comparison breaks once the counter crosses a power of ten (e.g.
+// "10" < "9" lexicographically).
+func rvLess(t *testing.T, a, b string) bool {
+	ai, err := strconv.Atoi(a)
+	if err != nil {
+		t.Fatalf("ResourceVersion %q is not numeric: %v", a, err)
+	}
+	bi, err := strconv.Atoi(b)
+	if err != nil {
+		t.Fatalf("ResourceVersion %q is not numeric: %v", b, err)
+	}
+	return ai < bi
+}
+
+func lastEventPerKey(events []watchEvent, kind model.Event) map[string]watchEvent {
+	last := make(map[string]watchEvent)
+	for _, ev := range events {
+		if ev.kind == kind {
+			last[ev.new.Name] = ev
+		}
+	}
+	return last
+}
+
+func countEvents(events []watchEvent, kind model.Event) int {
+	n := 0
+	for _, ev := range events {
+		if ev.kind == kind {
+			n++
+		}
+	}
+	return n
+}